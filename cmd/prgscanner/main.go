@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"regexp"
+	"runtime"
 	"time"
 
-	"github.com/cheggaaa/pb/v3"
 	"github.com/tiagotnx/scannerprg/internal/scanner"
+	"github.com/tiagotnx/scannerprg/internal/ui"
 )
 
 func main() {
@@ -25,6 +27,13 @@ func main() {
 	// Flags com valores padrão (possivelmente sobrescritos pelas variáveis de ambiente)
 	dirPtr := flag.String("dir", dirDefault, "diretório (ou unidade) a ser percorrido")
 	outputPtr := flag.String("out", outDefault, "arquivo de log de saída")
+	formatPtr := flag.String("format", "log", "formato de saída: \"log\" (texto) ou \"pprof\" (profile.proto, explorável com `go tool pprof`)")
+	deadCodePtr := flag.Bool("dead-code", false, "reporta funções transitivamente mortas (call graph) em vez de apenas as com zero referências")
+	unusedPtr := flag.Bool("unused", true, "reporta funções com zero referências diretas (ignorado se -dead-code for usado)")
+	rootsPtr := flag.String("roots", "", "regex adicional de nomes de declarações a tratar como raízes de alcançabilidade (usado com -dead-code)")
+	noCachePtr := flag.Bool("no-cache", false, "desativa o cache incremental (reprocessa todos os arquivos)")
+	cachePathPtr := flag.String("cache", ".scannerprg-cache", "caminho do arquivo de cache incremental")
+	statsPtr := flag.String("stats", "", "se definido, grava amostras periódicas de uso de recursos (RSS, heap, throughput) nesse arquivo")
 	flag.Parse()
 
 	startTime := time.Now()
@@ -36,31 +45,116 @@ func main() {
 		log.Fatalf("Erro ao buscar arquivos: %v", err)
 	}
 
+	numWorkers := runtime.NumCPU()
+
+	if *statsPtr != "" {
+		statsFile, err := os.Create(*statsPtr)
+		if err != nil {
+			log.Fatalf("Erro ao criar arquivo de stats: %v", err)
+		}
+		defer statsFile.Close()
+
+		reporter := scanner.NewStatsReporter(statsFile, 10*time.Second, map[string][]uint64{
+			"HeapAlloc": {512 * 1024 * 1024, 1024 * 1024 * 1024},
+		})
+		reporter.Start()
+		defer reporter.Stop()
+	}
+
+	var cache *scanner.Cache
+	var unchanged []string
+	toScan := prgFiles
+	if !*noCachePtr {
+		cache = scanner.LoadCache(*cachePathPtr)
+		var changed []string
+		unchanged, changed = cache.Partition(prgFiles)
+		if len(unchanged) > 0 {
+			fmt.Printf("Cache: %d arquivo(s) inalterado(s), %d a reprocessar\n", len(unchanged), len(changed))
+		}
+		toScan = changed
+	}
+
 	// Etapa 2: Extração concorrente das declarações
 	fmt.Println("Etapa 2: Identificando declarações de funções/procedures...")
-	barDecl := pb.StartNew(len(prgFiles))
-	scanner.ProcessDeclarationsConcurrently(prgFiles, barDecl)
-	barDecl.Finish()
+	if cache != nil {
+		cache.ApplyCachedDeclarations(unchanged)
+	}
+	runWithStatus(numWorkers, len(toScan), func(events chan ui.Event, logf func(string, ...interface{})) {
+		scanner.ProcessDeclarationsConcurrently(toScan, events, logf, cache)
+	})
 
 	// Etapa 3: Verificação concorrente do uso das funções/procedures
 	fmt.Println("Etapa 3: Verificando o uso das funções/procedures...")
-	barUsage := pb.StartNew(len(prgFiles))
-	scanner.ProcessUsageConcurrently(prgFiles, barUsage)
-	barUsage.Finish()
-
-	// Calcula as estatísticas do processamento
-	totalTime := time.Since(startTime)
-	stats := scanner.CalculateStatistics(totalTime)
+	if cache != nil {
+		cache.ApplyCachedUsage(unchanged)
+	}
+	runWithStatus(numWorkers, len(toScan), func(events chan ui.Event, logf func(string, ...interface{})) {
+		scanner.ProcessUsageConcurrently(toScan, events, logf, cache)
+	})
 
-	// Obtém as declarações não utilizadas
-	unusedGlobal, unusedStatic := scanner.GetUnusedDeclarations()
+	if cache != nil {
+		if err := cache.Save(*cachePathPtr); err != nil {
+			fmt.Printf("Aviso: não foi possível gravar o cache em %s: %v\n", *cachePathPtr, err)
+		}
+	}
 
-	// Etapa 4: Geração do log com estatísticas e agrupamentos
+	// Etapa 4: Geração do relatório de saída
 	fmt.Println("Etapa 4: Gerando arquivo de log...")
-	err = scanner.GenerateLog(*outputPtr, unusedGlobal, unusedStatic, stats)
+	switch *formatPtr {
+	case "pprof":
+		err = scanner.GenerateProfile(*outputPtr)
+	case "log":
+		var unusedGlobal, unusedStatic []scanner.DeclarationInfo
+		switch {
+		case *deadCodePtr:
+			var rootsPattern *regexp.Regexp
+			if *rootsPtr != "" {
+				rootsPattern, err = regexp.Compile(*rootsPtr)
+				if err != nil {
+					log.Fatalf("Regex de -roots inválida: %v", err)
+				}
+			}
+			unusedGlobal, unusedStatic = scanner.GetDeadDeclarations(rootsPattern)
+		case *unusedPtr:
+			unusedGlobal, unusedStatic = scanner.GetUnusedDeclarations()
+		default:
+			log.Fatalf("Nenhum relatório selecionado: use -unused ou -dead-code")
+		}
+
+		totalTime := time.Since(startTime)
+		stats := scanner.CalculateStatistics(totalTime)
+		stats.UnusedGlobal = len(unusedGlobal)
+		stats.UnusedStatic = len(unusedStatic)
+		if stats.TotalGlobal > 0 {
+			stats.GlobalUsagePercentage = float64(stats.TotalGlobal-stats.UnusedGlobal) / float64(stats.TotalGlobal) * 100.0
+		}
+		if stats.TotalStatic > 0 {
+			stats.StaticUsagePercentage = float64(stats.TotalStatic-stats.UnusedStatic) / float64(stats.TotalStatic) * 100.0
+		}
+		err = scanner.GenerateLog(*outputPtr, unusedGlobal, unusedStatic, stats)
+	default:
+		log.Fatalf("Formato desconhecido: %s (use \"log\" ou \"pprof\")", *formatPtr)
+	}
 	if err != nil {
 		log.Fatalf("Erro ao gerar arquivo de log: %v", err)
 	}
 
 	fmt.Printf("Processamento concluído. Log gerado em: %s\n", *outputPtr)
 }
+
+// runWithStatus aciona um Status na saída padrão, roda work em paralelo ao
+// seu consumo de eventos e aguarda os dois terminarem antes de retornar.
+func runWithStatus(numWorkers, total int, work func(events chan ui.Event, logf func(string, ...interface{}))) {
+	status := ui.New(os.Stdout, numWorkers, total)
+	events := make(chan ui.Event, numWorkers*2)
+	statusDone := make(chan struct{})
+	go func() {
+		status.Run(events)
+		close(statusDone)
+	}()
+
+	work(events, status.Printf)
+
+	close(events)
+	<-statusDone
+}
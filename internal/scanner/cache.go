@@ -0,0 +1,213 @@
+package scanner
+
+import (
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// CacheVersion muda sempre que o lexer/parser mudar de um jeito que alteraria
+// o resultado de processFileForDeclarations/processFileForUsage para o mesmo
+// conteúdo de arquivo, invalidando qualquer cache gravado por uma versão
+// anterior da ferramenta.
+const CacheVersion = "1"
+
+// CachedDecl é a forma compacta de uma FunctionDeclaration guardada em
+// cache: dispensa File (é a chave do CacheEntry) e UsageCount (reiniciado a
+// cada execução).
+type CachedDecl struct {
+	Name   string
+	Line   int
+	Static bool
+}
+
+// CacheEdge é uma aresta do call graph (ver CallGraph), guardada em cache
+// exatamente como observada por processFileForUsage.
+type CacheEdge struct {
+	Caller string
+	Callee string
+}
+
+// CacheEntry é o que fica persistido por arquivo .prg: o hash do conteúdo no
+// momento em que ele foi processado, as declarações encontradas, a tabela de
+// frequência de identificadores e as arestas de call graph produzidas.
+type CacheEntry struct {
+	Hash         string
+	Declarations []CachedDecl
+	Freq         map[string]int
+	Edges        []CacheEdge
+}
+
+// Cache é um cache incremental, em disco, indexado pelo caminho do arquivo.
+// Em árvores .prg de múltiplos GB ele evita reler e re-tokenizar arquivos
+// que não mudaram desde a última execução: processFileForDeclarations e
+// processFileForUsage só rodam para arquivos cujo hash mudou, e o resultado
+// dos demais é reaproveitado diretamente do cache.
+type Cache struct {
+	Version string
+	Entries map[string]CacheEntry
+
+	mu      sync.Mutex
+	pending map[string]string // hash calculado nesta execução, por arquivo
+}
+
+// NewCache cria um cache vazio, na versão atual da ferramenta.
+func NewCache() *Cache {
+	return &Cache{Version: CacheVersion, Entries: make(map[string]CacheEntry), pending: make(map[string]string)}
+}
+
+// LoadCache lê um cache gravado por (*Cache).Save em path. Se o arquivo não
+// existir ou não puder ser decodificado, ou se tiver sido gravado por uma
+// versão diferente da ferramenta, devolve um cache vazio silenciosamente —
+// para que upgrades do lexer não sirvam resultados obsoletos.
+func LoadCache(path string) *Cache {
+	f, err := os.Open(path)
+	if err != nil {
+		return NewCache()
+	}
+	defer f.Close()
+
+	loaded := NewCache()
+	if err := gob.NewDecoder(f).Decode(loaded); err != nil {
+		return NewCache()
+	}
+	if loaded.Version != CacheVersion {
+		return NewCache()
+	}
+	return loaded
+}
+
+// Save grava o cache em path.
+func (c *Cache) Save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return gob.NewEncoder(f).Encode(c)
+}
+
+// HashFile calcula o SHA-256 do conteúdo de um arquivo.
+func HashFile(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Partition calcula o hash de cada arquivo em paralelo e separa files entre
+// unchanged (hash bate com o cache) e changed (precisa ser reprocessado). O
+// hash de cada arquivo fica guardado internamente para uso por
+// captureDeclarations/captureUsage, sem precisar recalculá-lo.
+func (c *Cache) Partition(files []string) (unchanged, changed []string) {
+	type result struct {
+		file      string
+		isCurrent bool
+	}
+
+	fileCh := make(chan string, len(files))
+	resultCh := make(chan result, len(files))
+	var wg sync.WaitGroup
+
+	numWorkers := runtime.NumCPU()
+	worker := func() {
+		defer wg.Done()
+		for file := range fileCh {
+			hash, err := HashFile(file)
+			if err != nil {
+				resultCh <- result{file: file, isCurrent: false}
+				continue
+			}
+
+			c.mu.Lock()
+			entry, ok := c.Entries[file]
+			c.pending[file] = hash
+			c.mu.Unlock()
+
+			resultCh <- result{file: file, isCurrent: ok && entry.Hash == hash}
+		}
+	}
+
+	wg.Add(numWorkers)
+	for i := 0; i < numWorkers; i++ {
+		go worker()
+	}
+	for _, file := range files {
+		fileCh <- file
+	}
+	close(fileCh)
+	wg.Wait()
+	close(resultCh)
+
+	for r := range resultCh {
+		if r.isCurrent {
+			unchanged = append(unchanged, r.file)
+		} else {
+			changed = append(changed, r.file)
+		}
+	}
+	return
+}
+
+// ApplyCachedDeclarations reinsere, em globalFunctions/staticFunctions, as
+// declarações previamente guardadas para cada arquivo em files — como se
+// processFileForDeclarations tivesse acabado de rodar para eles.
+func (c *Cache) ApplyCachedDeclarations(files []string) {
+	for _, file := range files {
+		c.mu.Lock()
+		entry, ok := c.Entries[file]
+		c.mu.Unlock()
+		if !ok {
+			continue
+		}
+		for _, d := range entry.Declarations {
+			declareFunction(file, d.Name, strings.ToLower(d.Name), d.Line, d.Static)
+		}
+	}
+}
+
+// ApplyCachedUsage reaplica, para cada arquivo em files, a tabela de
+// frequência e as arestas de call graph previamente guardadas em cache —
+// como se processFileForUsage tivesse acabado de rodar para eles.
+func (c *Cache) ApplyCachedUsage(files []string) {
+	for _, file := range files {
+		c.mu.Lock()
+		entry, ok := c.Entries[file]
+		c.mu.Unlock()
+		if !ok {
+			continue
+		}
+		applyUsageFreq(file, entry.Freq)
+		for _, e := range entry.Edges {
+			callGraph.addEdge(e.Caller, e.Callee)
+		}
+	}
+}
+
+func (c *Cache) captureDeclarations(file string, decls []CachedDecl) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry := c.Entries[file]
+	entry.Hash = c.pending[file]
+	entry.Declarations = decls
+	c.Entries[file] = entry
+}
+
+func (c *Cache) captureUsage(file string, freq map[string]int, edges []CacheEdge) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry := c.Entries[file]
+	entry.Hash = c.pending[file]
+	entry.Freq = freq
+	entry.Edges = edges
+	c.Entries[file] = entry
+}
@@ -0,0 +1,159 @@
+package scanner
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// CallGraph registra, para cada função/procedure, quais outras declarações
+// ela referencia, a partir da função "aberta" no momento em que cada
+// identificador é visto por processFileForUsage. Uma chave "" representa
+// código de topo (fora de qualquer function/procedure) — o ponto de entrada
+// implícito de um arquivo .prg.
+type CallGraph struct {
+	mu    sync.Mutex
+	edges map[string]map[string]struct{}
+}
+
+func newCallGraph() *CallGraph {
+	return &CallGraph{edges: make(map[string]map[string]struct{})}
+}
+
+// callGraph é o grafo global, populado por processFileForUsage durante
+// ProcessUsageConcurrently.
+var callGraph = newCallGraph()
+
+func (g *CallGraph) addEdge(caller, callee string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	callees, ok := g.edges[caller]
+	if !ok {
+		callees = make(map[string]struct{})
+		g.edges[caller] = callees
+	}
+	callees[callee] = struct{}{}
+}
+
+func (g *CallGraph) calleesOf(caller string) []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	callees := g.edges[caller]
+	out := make([]string, 0, len(callees))
+	for callee := range callees {
+		out = append(out, callee)
+	}
+	return out
+}
+
+func globalKey(name string) string {
+	return "G:" + strings.ToLower(name)
+}
+
+func staticKey(file, name string) string {
+	return "S:" + file + ":" + strings.ToLower(name)
+}
+
+// resolveDeclKey resolve um identificador encontrado em file para a chave da
+// declaração correspondente, privilegiando uma função estática do próprio
+// arquivo antes de cair para o escopo global — as mesmas regras de
+// visibilidade do Clipper/Harbour.
+func resolveDeclKey(file, name string) (string, bool) {
+	lower := strings.ToLower(name)
+	if funcs, ok := staticFunctions[file]; ok {
+		if _, ok := funcs[lower]; ok {
+			return staticKey(file, lower), true
+		}
+	}
+	if _, ok := globalFunctions[lower]; ok {
+		return globalKey(lower), true
+	}
+	return "", false
+}
+
+// Roots retorna as chaves consideradas raízes de alcançabilidade: a função
+// "Main" (se existir), qualquer declaração cujo nome bata com rootsPattern
+// (pode ser nil) e qualquer função referenciada por código de topo (fora de
+// qualquer function/procedure).
+func (g *CallGraph) Roots(rootsPattern *regexp.Regexp) map[string]struct{} {
+	roots := make(map[string]struct{})
+
+	if _, ok := globalFunctions["main"]; ok {
+		roots[globalKey("main")] = struct{}{}
+	}
+
+	if rootsPattern != nil {
+		for _, decl := range globalFunctions {
+			if rootsPattern.MatchString(decl.Name) {
+				roots[globalKey(decl.Name)] = struct{}{}
+			}
+		}
+		for file, funcs := range staticFunctions {
+			for _, decl := range funcs {
+				if rootsPattern.MatchString(decl.Name) {
+					roots[staticKey(file, decl.Name)] = struct{}{}
+				}
+			}
+		}
+	}
+
+	for _, callee := range g.calleesOf("") {
+		roots[callee] = struct{}{}
+	}
+
+	return roots
+}
+
+// Reachable computa, a partir de roots, todas as chaves alcançáveis pelo
+// call graph com uma busca em largura (worklist BFS).
+func (g *CallGraph) Reachable(roots map[string]struct{}) map[string]struct{} {
+	reached := make(map[string]struct{}, len(roots))
+	queue := make([]string, 0, len(roots))
+	for key := range roots {
+		reached[key] = struct{}{}
+		queue = append(queue, key)
+	}
+
+	for len(queue) > 0 {
+		caller := queue[0]
+		queue = queue[1:]
+		for _, callee := range g.calleesOf(caller) {
+			if _, ok := reached[callee]; ok {
+				continue
+			}
+			reached[callee] = struct{}{}
+			queue = append(queue, callee)
+		}
+	}
+
+	return reached
+}
+
+// DeadSet retorna, separadas em globais e estáticas, todas as declarações
+// que não aparecem em reachable — funções transitivamente mortas, incluindo
+// as que só são chamadas por outras funções igualmente mortas.
+func (g *CallGraph) DeadSet(reachable map[string]struct{}) (deadGlobal, deadStatic []DeclarationInfo) {
+	for _, decl := range globalFunctions {
+		if _, ok := reachable[globalKey(decl.Name)]; !ok {
+			deadGlobal = append(deadGlobal, DeclarationInfo{Name: decl.Name, File: decl.File})
+		}
+	}
+	for file, funcs := range staticFunctions {
+		for _, decl := range funcs {
+			if _, ok := reachable[staticKey(file, decl.Name)]; !ok {
+				deadStatic = append(deadStatic, DeclarationInfo{Name: decl.Name, File: file})
+			}
+		}
+	}
+	return
+}
+
+// GetDeadDeclarations é o equivalente de GetUnusedDeclarations baseado no
+// call graph: em vez de marcar como morta toda declaração com UsageCount
+// <= 1, ela calcula a alcançabilidade a partir de Roots(rootsPattern) e só
+// marca como morta o que não é transitivamente alcançável.
+func GetDeadDeclarations(rootsPattern *regexp.Regexp) (deadGlobal, deadStatic []DeclarationInfo) {
+	roots := callGraph.Roots(rootsPattern)
+	reachable := callGraph.Reachable(roots)
+	return callGraph.DeadSet(reachable)
+}
@@ -0,0 +1,180 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// resetGlobalState limpa os mapas globais de declarações e o call graph,
+// para que cada teste comece de um estado conhecido — os mesmos mapas que
+// processFileForDeclarations/processFileForUsage usam em produção.
+func resetGlobalState() {
+	globalFunctions = make(map[string]*FunctionDeclaration)
+	staticFunctions = make(map[string]map[string]*FunctionDeclaration)
+	callGraph = newCallGraph()
+}
+
+func TestReachableFollowsCallGraphTransitively(t *testing.T) {
+	resetGlobalState()
+	defer resetGlobalState()
+
+	globalFunctions["main"] = &FunctionDeclaration{Name: "Main"}
+	globalFunctions["helper"] = &FunctionDeclaration{Name: "Helper"}
+	globalFunctions["deep"] = &FunctionDeclaration{Name: "Deep"}
+	globalFunctions["dead"] = &FunctionDeclaration{Name: "Dead"}
+
+	callGraph.addEdge(globalKey("main"), globalKey("helper"))
+	callGraph.addEdge(globalKey("helper"), globalKey("deep"))
+
+	reachable := callGraph.Reachable(callGraph.Roots(nil))
+
+	for _, key := range []string{globalKey("main"), globalKey("helper"), globalKey("deep")} {
+		if _, ok := reachable[key]; !ok {
+			t.Errorf("esperava %q alcançável, não estava em %v", key, reachable)
+		}
+	}
+	if _, ok := reachable[globalKey("dead")]; ok {
+		t.Errorf("Dead não deveria ser alcançável")
+	}
+}
+
+func TestStaticFunctionsAreFileScoped(t *testing.T) {
+	resetGlobalState()
+	defer resetGlobalState()
+
+	staticFunctions["a.prg"] = map[string]*FunctionDeclaration{
+		"helper": {Name: "Helper", File: "a.prg", Static: true},
+	}
+	staticFunctions["b.prg"] = map[string]*FunctionDeclaration{
+		"helper": {Name: "Helper", File: "b.prg", Static: true},
+	}
+
+	callGraph.addEdge("", staticKey("a.prg", "helper"))
+
+	reachable := callGraph.Reachable(callGraph.Roots(nil))
+
+	if _, ok := reachable[staticKey("a.prg", "helper")]; !ok {
+		t.Errorf("Helper de a.prg deveria ser alcançável")
+	}
+	if _, ok := reachable[staticKey("b.prg", "helper")]; ok {
+		t.Errorf("Helper de b.prg é uma declaração homônima e distinta: não deveria ser alcançável")
+	}
+
+	deadGlobal, deadStatic := callGraph.DeadSet(reachable)
+	if len(deadGlobal) != 0 {
+		t.Errorf("deadGlobal = %v, want vazio", deadGlobal)
+	}
+	if len(deadStatic) != 1 || deadStatic[0].File != "b.prg" {
+		t.Errorf("deadStatic = %v, want apenas Helper de b.prg", deadStatic)
+	}
+}
+
+func TestResolveDeclKeyPrefersStaticOverGlobal(t *testing.T) {
+	resetGlobalState()
+	defer resetGlobalState()
+
+	globalFunctions["helper"] = &FunctionDeclaration{Name: "Helper"}
+	staticFunctions["a.prg"] = map[string]*FunctionDeclaration{
+		"helper": {Name: "Helper", File: "a.prg", Static: true},
+	}
+
+	key, ok := resolveDeclKey("a.prg", "Helper")
+	if !ok || key != staticKey("a.prg", "helper") {
+		t.Errorf("resolveDeclKey(a.prg, Helper) = (%q, %v), want (%q, true)", key, ok, staticKey("a.prg", "helper"))
+	}
+
+	key, ok = resolveDeclKey("b.prg", "Helper")
+	if !ok || key != globalKey("helper") {
+		t.Errorf("resolveDeclKey(b.prg, Helper) = (%q, %v), want (%q, true) — sem estática local, deve cair para a global", key, ok, globalKey("helper"))
+	}
+
+	if _, ok := resolveDeclKey("a.prg", "Inexistente"); ok {
+		t.Errorf("resolveDeclKey deveria falhar para um nome não declarado")
+	}
+}
+
+func TestRootsIncludesMainAndTopLevelCallees(t *testing.T) {
+	resetGlobalState()
+	defer resetGlobalState()
+
+	globalFunctions["main"] = &FunctionDeclaration{Name: "Main"}
+	globalFunctions["inittest"] = &FunctionDeclaration{Name: "InitTest"}
+
+	callGraph.addEdge("", globalKey("inittest"))
+
+	roots := callGraph.Roots(nil)
+
+	if _, ok := roots[globalKey("main")]; !ok {
+		t.Errorf("Main deveria ser raiz automaticamente")
+	}
+	if _, ok := roots[globalKey("inittest")]; !ok {
+		t.Errorf("InitTest é chamada de código de topo e deveria ser raiz")
+	}
+}
+
+func TestDeadSetMarksUnreachableDeclarationsDead(t *testing.T) {
+	resetGlobalState()
+	defer resetGlobalState()
+
+	globalFunctions["main"] = &FunctionDeclaration{Name: "Main"}
+	globalFunctions["unused"] = &FunctionDeclaration{Name: "Unused"}
+
+	reachable := callGraph.Reachable(callGraph.Roots(nil))
+	deadGlobal, deadStatic := callGraph.DeadSet(reachable)
+
+	if len(deadStatic) != 0 {
+		t.Errorf("deadStatic = %v, want vazio", deadStatic)
+	}
+	if len(deadGlobal) != 1 || deadGlobal[0].Name != "Unused" {
+		t.Errorf("deadGlobal = %v, want apenas Unused", deadGlobal)
+	}
+}
+
+// TestGetDeadDeclarationsEndToEnd exercita o caminho completo a partir de
+// código-fonte real — Tokenize, processFileForDeclarations e
+// processFileForUsage — em vez de popular os mapas globais diretamente, para
+// cobrir currentCaller e resolveDeclKey como são realmente usados. Em
+// particular, Helper só é referenciada dentro de um índice de array
+// (aData[Helper()]), o caso que regrediu quando Tokenize tratava [...] como
+// string literal.
+func TestGetDeadDeclarationsEndToEnd(t *testing.T) {
+	resetGlobalState()
+	defer resetGlobalState()
+
+	const source = `FUNCTION Main()
+  LOCAL aData := {1, 2, 3}
+  LOCAL x := aData[Helper()]
+RETURN
+
+FUNCTION Helper()
+RETURN 1
+
+FUNCTION Morta()
+RETURN
+`
+	file := filepath.Join(t.TempDir(), "main.prg")
+	if err := os.WriteFile(file, []byte(source), 0o644); err != nil {
+		t.Fatalf("os.WriteFile: %v", err)
+	}
+
+	processFileForDeclarations(file, nil)
+	processFileForUsage(file, nil)
+
+	deadGlobal, _ := GetDeadDeclarations(nil)
+	for _, d := range deadGlobal {
+		if d.Name == "Helper" {
+			t.Errorf("Helper é chamada dentro de aData[Helper()] e não deveria ser reportada como morta")
+		}
+	}
+
+	foundMorta := false
+	for _, d := range deadGlobal {
+		if d.Name == "Morta" {
+			foundMorta = true
+		}
+	}
+	if !foundMorta {
+		t.Errorf("Morta não é chamada por ninguém e deveria ser reportada como morta, deadGlobal = %v", deadGlobal)
+	}
+}
@@ -0,0 +1,82 @@
+package scanner
+
+import "unicode"
+
+// Token representa um identificador extraído do código-fonte, já descontados
+// comentários e literais de string.
+type Token struct {
+	Value string
+	Line  int
+}
+
+// Tokenize percorre o conteúdo de um arquivo .prg e devolve apenas os tokens
+// de identificadores (letras, dígitos e underscore), ignorando comentários de
+// linha ("//" e "&&"), comentários de bloco ("/* */") e o conteúdo de
+// literais de string ("..." e '...'). Isso evita que um nome de função
+// mencionado dentro de um comentário ou de uma string seja confundido com uma
+// chamada real. [...] não é tratado como string literal: em Clipper/Harbour
+// ele é, de longe mais comumente, indexação de array (aData[Helper()]), e
+// tratá-lo como literal engoliria o identificador dentro dos colchetes.
+func Tokenize(content string) []Token {
+	var tokens []Token
+	runes := []rune(content)
+	n := len(runes)
+	line := 1
+
+	for i := 0; i < n; {
+		r := runes[i]
+
+		switch {
+		case r == '\n':
+			line++
+			i++
+		case r == '/' && i+1 < n && runes[i+1] == '/':
+			for i < n && runes[i] != '\n' {
+				i++
+			}
+		case r == '&' && i+1 < n && runes[i+1] == '&':
+			for i < n && runes[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < n && runes[i+1] == '*':
+			i += 2
+			for i < n && !(runes[i] == '*' && i+1 < n && runes[i+1] == '/') {
+				if runes[i] == '\n' {
+					line++
+				}
+				i++
+			}
+			if i < n {
+				i += 2 // pula "*/"
+			}
+		case r == '"' || r == '\'':
+			quote := r
+			i++
+			for i < n && runes[i] != quote {
+				if runes[i] == '\n' {
+					line++
+				}
+				i++
+			}
+			i++ // pula o delimitador final
+		case isIdentStart(r):
+			start := i
+			for i < n && isIdentPart(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, Token{Value: string(runes[start:i]), Line: line})
+		default:
+			i++
+		}
+	}
+
+	return tokens
+}
+
+func isIdentStart(r rune) bool {
+	return unicode.IsLetter(r) || r == '_'
+}
+
+func isIdentPart(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
@@ -0,0 +1,108 @@
+package scanner
+
+import "testing"
+
+func tokenValues(tokens []Token) []string {
+	values := make([]string, len(tokens))
+	for i, tok := range tokens {
+		values[i] = tok.Value
+	}
+	return values
+}
+
+func TestTokenizeIdentifiers(t *testing.T) {
+	tokens := Tokenize("FUNCTION Main()\n  Helper()\nRETURN")
+	got := tokenValues(tokens)
+	want := []string{"FUNCTION", "Main", "Helper", "RETURN"}
+	if len(got) != len(want) {
+		t.Fatalf("Tokenize() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Tokenize() = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestTokenizeLineComment(t *testing.T) {
+	tokens := Tokenize("Main() // chama Helper\nHelper()")
+	got := tokenValues(tokens)
+	want := []string{"Main", "Helper"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("Tokenize() = %v, want %v (// deveria descartar o resto da linha)", got, want)
+	}
+}
+
+func TestTokenizeClipperLineComment(t *testing.T) {
+	tokens := Tokenize("Main() && chama Helper\nHelper()")
+	got := tokenValues(tokens)
+	want := []string{"Main", "Helper"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("Tokenize() = %v, want %v (&& deveria descartar o resto da linha)", got, want)
+	}
+}
+
+func TestTokenizeBlockComment(t *testing.T) {
+	tokens := Tokenize("Main()\n/* Helper\n   não deveria aparecer */\nOutra()")
+	got := tokenValues(tokens)
+	want := []string{"Main", "Outra"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("Tokenize() = %v, want %v (bloco /* */ deveria ser ignorado)", got, want)
+	}
+}
+
+func TestTokenizeStringLiterals(t *testing.T) {
+	tokens := Tokenize(`Main("Helper") + 'Outra'`)
+	got := tokenValues(tokens)
+	want := []string{"Main"}
+	if len(got) != len(want) || got[0] != want[0] {
+		t.Fatalf("Tokenize() = %v, want %v (conteúdo de string não deveria virar token)", got, want)
+	}
+}
+
+func TestTokenizeLineNumbers(t *testing.T) {
+	content := "FUNCTION Main()\n\nRETURN\n\nFUNCTION Helper()\nRETURN"
+	tokens := Tokenize(content)
+
+	var helperLine int
+	for _, tok := range tokens {
+		if tok.Value == "Helper" {
+			helperLine = tok.Line
+		}
+	}
+	if helperLine != 5 {
+		t.Fatalf("token Helper na linha %d, esperava 5", helperLine)
+	}
+}
+
+func TestTokenizeArrayIndexing(t *testing.T) {
+	tokens := Tokenize("x := aData[Helper()]\ny := aJump[ nOp ]()")
+	got := tokenValues(tokens)
+	want := []string{"x", "aData", "Helper", "y", "aJump", "nOp"}
+	if len(got) != len(want) {
+		t.Fatalf("Tokenize() = %v, want %v ([...] é indexação de array, não string literal)", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Tokenize() = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestTokenizeUnterminatedString(t *testing.T) {
+	tokens := Tokenize(`Main("sem fechar`)
+	got := tokenValues(tokens)
+	want := []string{"Main"}
+	if len(got) != len(want) || got[0] != want[0] {
+		t.Fatalf("Tokenize() = %v, want %v (string não terminada não deveria travar ou gerar tokens extras)", got, want)
+	}
+}
+
+func TestTokenizeUnterminatedBlockComment(t *testing.T) {
+	tokens := Tokenize("Main()\n/* nunca fecha\nHelper()")
+	got := tokenValues(tokens)
+	want := []string{"Main"}
+	if len(got) != len(want) || got[0] != want[0] {
+		t.Fatalf("Tokenize() = %v, want %v (comentário de bloco não terminado deveria consumir o resto do arquivo)", got, want)
+	}
+}
@@ -0,0 +1,81 @@
+package scanner
+
+import (
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/pprof/profile"
+)
+
+// GenerateProfile grava as declarações e suas contagens de uso no formato
+// profile.proto do pprof. Cada função/procedure declarada vira uma
+// Location/Function, com o UsageCount como valor de amostra do tipo
+// "usage/count" e Function.Filename apontando para o arquivo .prg de origem,
+// para que `go tool pprof -top -cum`, `-list`, `-focus=`/`-ignore=` e `-base`
+// (para diffar duas execuções) funcionem sobre o relatório de uso.
+func GenerateProfile(outputPath string) error {
+	p := &profile.Profile{
+		SampleType: []*profile.ValueType{
+			{Type: "usage", Unit: "count"},
+		},
+		TimeNanos: time.Now().UnixNano(),
+	}
+
+	mappingByFile := make(map[string]*profile.Mapping)
+	nextID := uint64(1)
+
+	addDecl := func(decl *FunctionDeclaration) {
+		mapping, ok := mappingByFile[decl.File]
+		if !ok {
+			// File fica vazio de propósito: não é um binário, e setá-lo faz o
+			// `go tool pprof` tentar simbolizar contra o .prg e falhar com
+			// "unrecognized binary format". Function.Filename já liga de volta
+			// à fonte.
+			mapping = &profile.Mapping{
+				ID: uint64(len(p.Mapping)) + 1,
+			}
+			mappingByFile[decl.File] = mapping
+			p.Mapping = append(p.Mapping, mapping)
+		}
+
+		fn := &profile.Function{
+			ID:         nextID,
+			Name:       decl.Name,
+			SystemName: decl.Name,
+			Filename:   decl.File,
+		}
+		p.Function = append(p.Function, fn)
+
+		loc := &profile.Location{
+			ID:      nextID,
+			Mapping: mapping,
+			Line:    []profile.Line{{Function: fn, Line: int64(decl.Line)}},
+		}
+		p.Location = append(p.Location, loc)
+
+		p.Sample = append(p.Sample, &profile.Sample{
+			Location: []*profile.Location{loc},
+			Value:    []int64{atomic.LoadInt64(&decl.UsageCount)},
+		})
+
+		nextID++
+	}
+
+	for _, decl := range globalFunctions {
+		addDecl(decl)
+	}
+	for _, funcs := range staticFunctions {
+		for _, decl := range funcs {
+			addDecl(decl)
+		}
+	}
+
+	f, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return p.Write(f)
+}
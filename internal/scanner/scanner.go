@@ -5,21 +5,20 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
-	"regexp"
 	"runtime"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
-	"unicode"
 
-	"github.com/cheggaaa/pb/v3"
+	"github.com/tiagotnx/scannerprg/internal/ui"
 )
 
 // FunctionDeclaration representa uma função ou procedure encontrada.
 type FunctionDeclaration struct {
 	Name       string
 	File       string
+	Line       int // Linha do token function/procedure, 1-based
 	Static     bool
 	UsageCount int64 // Inicia em 1 para contabilizar a declaração
 }
@@ -45,10 +44,19 @@ var (
 	globalFunctions = make(map[string]*FunctionDeclaration)
 	staticFunctions = make(map[string]map[string]*FunctionDeclaration)
 	declMutex       sync.Mutex // Protege os mapas durante as declarações
+
+	bytesReadTotal      int64
+	filesProcessedTotal int64
 )
 
-// Regex para identificar declarações de função/procedure.
-var declRegex = regexp.MustCompile(`(?i)^\s*(static\s+)?(function|procedure)\s+([a-zA-Z0-9_]+)`)
+// BytesRead retorna o total de bytes lidos do disco até agora, somando as
+// etapas de declarações e de uso. Usado por StatsReporter.
+func BytesRead() int64 { return atomic.LoadInt64(&bytesReadTotal) }
+
+// FilesProcessed retorna o total de leituras de arquivo concluídas até
+// agora, somando as etapas de declarações e de uso. Usado por
+// StatsReporter.
+func FilesProcessed() int64 { return atomic.LoadInt64(&filesProcessedTotal) }
 
 // SearchPRGFiles percorre recursivamente o diretório e retorna os caminhos dos arquivos .prg.
 func SearchPRGFiles(root string) ([]string, error) {
@@ -65,23 +73,30 @@ func SearchPRGFiles(root string) ([]string, error) {
 	return prgFiles, err
 }
 
-// ProcessDeclarationsConcurrently processa as declarações de forma concorrente, atualizando a barra de progresso.
-func ProcessDeclarationsConcurrently(files []string, bar *pb.ProgressBar) {
+// ProcessDeclarationsConcurrently processa as declarações de forma
+// concorrente, emitindo um ui.Event por arquivo no canal events (se não for
+// nil), reportando erros de leitura via logf (se nil, usa fmt.Printf) e
+// alimentando cache (se não nil) com as declarações de cada arquivo, para
+// reaproveitamento em execuções futuras.
+func ProcessDeclarationsConcurrently(files []string, events chan<- ui.Event, logf func(format string, args ...interface{}), cache *Cache) {
 	numWorkers := runtime.NumCPU()
 	fileCh := make(chan string, len(files))
 	var wg sync.WaitGroup
 
-	worker := func() {
+	worker := func(workerID int) {
 		defer wg.Done()
 		for file := range fileCh {
-			processFileForDeclarations(file)
-			bar.Increment()
+			emitEvent(events, workerID, file, ui.PhaseDecl)
+			decls := processFileForDeclarations(file, logf)
+			if cache != nil {
+				cache.captureDeclarations(file, decls)
+			}
 		}
 	}
 
 	wg.Add(numWorkers)
 	for i := 0; i < numWorkers; i++ {
-		go worker()
+		go worker(i)
 	}
 	for _, file := range files {
 		fileCh <- file
@@ -90,63 +105,114 @@ func ProcessDeclarationsConcurrently(files []string, bar *pb.ProgressBar) {
 	wg.Wait()
 }
 
-func processFileForDeclarations(file string) {
+func emitEvent(events chan<- ui.Event, workerID int, file string, phase ui.Phase) {
+	if events == nil {
+		return
+	}
+	var size int64
+	if info, err := os.Stat(file); err == nil {
+		size = info.Size()
+	}
+	events <- ui.Event{WorkerID: workerID, File: file, Size: size, Phase: phase}
+}
+
+func logOrDefault(logf func(format string, args ...interface{}), format string, args ...interface{}) {
+	if logf != nil {
+		logf(format, args...)
+		return
+	}
+	fmt.Printf(format, args...)
+}
+
+// processFileForDeclarations lê e tokeniza file, registra cada
+// function/procedure encontrada em globalFunctions/staticFunctions e
+// devolve a mesma lista de declarações em formato compacto, para que o
+// chamador possa gravá-la em cache.
+func processFileForDeclarations(file string, logf func(format string, args ...interface{})) []CachedDecl {
 	content, err := os.ReadFile(file)
 	if err != nil {
-		fmt.Printf("Erro ao ler o arquivo %s: %v\n", file, err)
+		logOrDefault(logf, "Erro ao ler o arquivo %s: %v\n", file, err)
+		return nil
+	}
+	atomic.AddInt64(&bytesReadTotal, int64(len(content)))
+	atomic.AddInt64(&filesProcessedTotal, 1)
+
+	tokens := Tokenize(string(content))
+	var decls []CachedDecl
+	for i, tok := range tokens {
+		keyword := strings.ToLower(tok.Value)
+		if keyword != "function" && keyword != "procedure" {
+			continue
+		}
+		if i+1 >= len(tokens) {
+			continue
+		}
+		isStatic := i > 0 && strings.EqualFold(tokens[i-1].Value, "static")
+		name := tokens[i+1].Value
+		lowerName := strings.ToLower(name)
+		line := tokens[i].Line
+
+		declareFunction(file, name, lowerName, line, isStatic)
+		decls = append(decls, CachedDecl{Name: name, Line: line, Static: isStatic})
+	}
+	return decls
+}
+
+// declareFunction registra, de forma protegida por declMutex, uma
+// declaração de função/procedure encontrada em file.
+func declareFunction(file, name, lowerName string, line int, isStatic bool) {
+	declMutex.Lock()
+	defer declMutex.Unlock()
+	if isStatic {
+		if staticFunctions[file] == nil {
+			staticFunctions[file] = make(map[string]*FunctionDeclaration)
+		}
+		staticFunctions[file][lowerName] = &FunctionDeclaration{
+			Name:       name,
+			File:       file,
+			Line:       line,
+			Static:     true,
+			UsageCount: 1,
+		}
 		return
 	}
-	lines := strings.Split(string(content), "\n")
-	for _, line := range lines {
-		match := declRegex.FindStringSubmatch(line)
-		if match != nil {
-			isStatic := strings.TrimSpace(match[1]) != ""
-			name := match[3]
-			declMutex.Lock()
-			if isStatic {
-				if staticFunctions[file] == nil {
-					staticFunctions[file] = make(map[string]*FunctionDeclaration)
-				}
-				staticFunctions[file][name] = &FunctionDeclaration{
-					Name:       name,
-					File:       file,
-					Static:     true,
-					UsageCount: 1,
-				}
-			} else {
-				if gf, exists := globalFunctions[name]; exists {
-					gf.UsageCount++ // Em caso de declarações duplicadas
-				} else {
-					globalFunctions[name] = &FunctionDeclaration{
-						Name:       name,
-						File:       file,
-						Static:     false,
-						UsageCount: 1,
-					}
-				}
-			}
-			declMutex.Unlock()
+	if gf, exists := globalFunctions[lowerName]; exists {
+		gf.UsageCount++ // Em caso de declarações duplicadas
+	} else {
+		globalFunctions[lowerName] = &FunctionDeclaration{
+			Name:       name,
+			File:       file,
+			Line:       line,
+			Static:     false,
+			UsageCount: 1,
 		}
 	}
 }
 
-// ProcessUsageConcurrently processa a verificação de uso de forma concorrente, atualizando a barra de progresso.
-func ProcessUsageConcurrently(files []string, bar *pb.ProgressBar) {
+// ProcessUsageConcurrently processa a verificação de uso de forma
+// concorrente, emitindo um ui.Event por arquivo no canal events (se não for
+// nil), reportando erros de leitura via logf (se nil, usa fmt.Printf) e
+// alimentando cache (se não nil) com a frequência de identificadores e as
+// arestas do call graph de cada arquivo, para reaproveitamento futuro.
+func ProcessUsageConcurrently(files []string, events chan<- ui.Event, logf func(format string, args ...interface{}), cache *Cache) {
 	numWorkers := runtime.NumCPU()
 	fileCh := make(chan string, len(files))
 	var wg sync.WaitGroup
 
-	worker := func() {
+	worker := func(workerID int) {
 		defer wg.Done()
 		for file := range fileCh {
-			processFileForUsage(file)
-			bar.Increment()
+			emitEvent(events, workerID, file, ui.PhaseUsage)
+			freq, edges := processFileForUsage(file, logf)
+			if cache != nil {
+				cache.captureUsage(file, freq, edges)
+			}
 		}
 	}
 
 	wg.Add(numWorkers)
 	for i := 0; i < numWorkers; i++ {
-		go worker()
+		go worker(i)
 	}
 	for _, file := range files {
 		fileCh <- file
@@ -155,24 +221,55 @@ func ProcessUsageConcurrently(files []string, bar *pb.ProgressBar) {
 	wg.Wait()
 }
 
-func processFileForUsage(file string) {
+// processFileForUsage lê e tokeniza file, atualiza o UsageCount das
+// declarações referenciadas, alimenta o call graph global e devolve a
+// tabela de frequência de identificadores e as arestas produzidas, para que
+// o chamador possa gravá-las em cache.
+func processFileForUsage(file string, logf func(format string, args ...interface{})) (map[string]int, []CacheEdge) {
 	contentBytes, err := os.ReadFile(file)
 	if err != nil {
-		fmt.Printf("Erro ao ler o arquivo %s: %v\n", file, err)
-		return
+		logOrDefault(logf, "Erro ao ler o arquivo %s: %v\n", file, err)
+		return nil, nil
 	}
-	content := strings.ToLower(string(contentBytes))
+	atomic.AddInt64(&bytesReadTotal, int64(len(contentBytes)))
+	atomic.AddInt64(&filesProcessedTotal, 1)
+
+	tokens := Tokenize(string(contentBytes))
 	freq := make(map[string]int)
+	var edges []CacheEdge
+
+	// currentCaller é a chave (ver resolveDeclKey) da função/procedure
+	// atualmente "aberta" neste arquivo, ou "" para código de topo (fora de
+	// qualquer function/procedure) — usado para alimentar o call graph.
+	currentCaller := ""
+	for i, tok := range tokens {
+		lower := strings.ToLower(tok.Value)
+		freq[lower]++
+
+		if lower == "function" || lower == "procedure" {
+			currentCaller = ""
+			if i+1 < len(tokens) {
+				if key, ok := resolveDeclKey(file, tokens[i+1].Value); ok {
+					currentCaller = key
+				}
+			}
+			continue
+		}
 
-	// Tokenização: letras, dígitos e underscore.
-	tokens := strings.FieldsFunc(content, func(r rune) bool {
-		return !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_')
-	})
-	for _, token := range tokens {
-		freq[token]++
+		if calleeKey, ok := resolveDeclKey(file, tok.Value); ok && calleeKey != currentCaller {
+			callGraph.addEdge(currentCaller, calleeKey)
+			edges = append(edges, CacheEdge{Caller: currentCaller, Callee: calleeKey})
+		}
 	}
 
-	// Atualiza o uso para funções globais
+	applyUsageFreq(file, freq)
+	return freq, edges
+}
+
+// applyUsageFreq soma, em globalFunctions e em staticFunctions[file], a
+// frequência de cada identificador em freq (descontando uma ocorrência para
+// a própria declaração, quando ela está no mesmo arquivo).
+func applyUsageFreq(file string, freq map[string]int) {
 	for _, decl := range globalFunctions {
 		lowerName := strings.ToLower(decl.Name)
 		count := freq[lowerName]
@@ -184,7 +281,6 @@ func processFileForUsage(file string) {
 		}
 	}
 
-	// Atualiza o uso para funções estáticas
 	if funcs, ok := staticFunctions[file]; ok {
 		for _, decl := range funcs {
 			lowerName := strings.ToLower(decl.Name)
@@ -0,0 +1,111 @@
+package scanner
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// StatsReporter amostra periodicamente o uso de recursos do processo (RSS,
+// heap) e o throughput do scanner (bytes lidos, arquivos processados,
+// goroutines), escrevendo uma linha por amostra em out — inspirado no
+// crunchstat.Reporter do Arvados. Útil para depurar OOMs em árvores .prg
+// enormes e para capacity planning em execuções de CI.
+type StatsReporter struct {
+	out        io.Writer
+	pollPeriod time.Duration
+	thresholds map[string][]uint64
+
+	mu      sync.Mutex
+	crossed map[string]map[uint64]bool // estatística -> limiar -> já avisado desde que cruzou por último
+
+	stopCh chan struct{}
+	doneCh chan struct{}
+}
+
+// NewStatsReporter cria um StatsReporter que escreve em out a cada
+// pollPeriod (10s se pollPeriod <= 0). memThresholds mapeia o nome de uma
+// estatística de memória (por ora, "HeapAlloc") para os limiares, em bytes,
+// que disparam um único aviso ao serem cruzados — o aviso só se repete
+// depois que o valor cai abaixo do limiar e o cruza de novo.
+func NewStatsReporter(out io.Writer, pollPeriod time.Duration, memThresholds map[string][]uint64) *StatsReporter {
+	if pollPeriod <= 0 {
+		pollPeriod = 10 * time.Second
+	}
+	return &StatsReporter{
+		out:        out,
+		pollPeriod: pollPeriod,
+		thresholds: memThresholds,
+		crossed:    make(map[string]map[uint64]bool),
+	}
+}
+
+// Start inicia a amostragem periódica em uma goroutine própria. Deve ser
+// parado com Stop.
+func (r *StatsReporter) Start() {
+	r.stopCh = make(chan struct{})
+	r.doneCh = make(chan struct{})
+	go r.run()
+}
+
+// Stop para a amostragem e aguarda a última amostra ser escrita.
+func (r *StatsReporter) Stop() {
+	close(r.stopCh)
+	<-r.doneCh
+}
+
+func (r *StatsReporter) run() {
+	defer close(r.doneCh)
+	ticker := time.NewTicker(r.pollPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.sample()
+		case <-r.stopCh:
+			r.sample()
+			return
+		}
+	}
+}
+
+func (r *StatsReporter) sample() {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	fmt.Fprintf(r.out, "%s sys=%d heap_alloc=%d bytes_read=%d files=%d goroutines=%d\n",
+		time.Now().Format(time.RFC3339), mem.Sys, mem.HeapAlloc,
+		BytesRead(), FilesProcessed(), runtime.NumGoroutine())
+
+	r.checkThreshold("HeapAlloc", mem.HeapAlloc)
+}
+
+func (r *StatsReporter) checkThreshold(stat string, value uint64) {
+	limits, ok := r.thresholds[stat]
+	if !ok {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	notified := r.crossed[stat]
+	if notified == nil {
+		notified = make(map[uint64]bool)
+		r.crossed[stat] = notified
+	}
+
+	for _, limit := range limits {
+		if value < limit {
+			notified[limit] = false
+			continue
+		}
+		if notified[limit] {
+			continue
+		}
+		notified[limit] = true
+		fmt.Fprintf(r.out, "%s AVISO: %s atingiu %d, limite configurado %d\n",
+			time.Now().Format(time.RFC3339), stat, value, limit)
+	}
+}
@@ -0,0 +1,193 @@
+// Package ui fornece uma área de status de terminal multi-linha, inspirada
+// no internal/ui/termstatus do restic: uma linha de resumo seguida de uma
+// linha por worker concorrente, redesenhada no lugar em terminais TTY e
+// degradada para saída linear em qualquer outro destino.
+package ui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Phase identifica em qual etapa do processamento um Event ocorreu.
+type Phase string
+
+const (
+	PhaseDecl  Phase = "decl"
+	PhaseUsage Phase = "usage"
+)
+
+// Event representa o progresso de um worker ao processar um arquivo.
+type Event struct {
+	WorkerID int
+	File     string
+	Size     int64
+	Phase    Phase
+}
+
+// Status acompanha e exibe o progresso de um processamento concorrente.
+// Em terminais TTY mantém uma área fixa ao final da saída com uma linha de
+// resumo (arquivos processados, tempo decorrido, ETA e throughput) e uma
+// linha por worker mostrando o arquivo que ele está processando. Em qualquer
+// outro destino (pipe, arquivo, CI) degrada para uma linha por arquivo
+// iniciado.
+type Status struct {
+	out         io.Writer
+	interactive bool
+	numWorkers  int
+	total       int
+
+	mu        sync.Mutex
+	workers   []Event
+	processed int
+	bytesRead int64
+	start     time.Time
+	lastLines int
+}
+
+// New cria um Status pronto para acompanhar o processamento de total
+// arquivos por até numWorkers workers concorrentes, escrevendo em out.
+func New(out *os.File, numWorkers, total int) *Status {
+	return &Status{
+		out:         out,
+		interactive: isTerminal(out),
+		numWorkers:  numWorkers,
+		total:       total,
+		workers:     make([]Event, numWorkers),
+		start:       time.Now(),
+	}
+}
+
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+// Run consome eventos de progresso até o canal ser fechado, mantendo a área
+// de status atualizada. Bloqueia até o canal fechar, por isso deve rodar em
+// sua própria goroutine.
+func (s *Status) Run(events <-chan Event) {
+	if !s.interactive {
+		s.runPlain(events)
+		return
+	}
+	s.runInteractive(events)
+}
+
+func (s *Status) runPlain(events <-chan Event) {
+	for ev := range events {
+		s.mu.Lock()
+		s.processed++
+		processed := s.processed
+		s.mu.Unlock()
+		fmt.Fprintf(s.out, "[%d/%d] %s: %s\n", processed, s.total, ev.Phase, ev.File)
+	}
+}
+
+func (s *Status) runInteractive(events <-chan Event) {
+	ticker := time.NewTicker(200 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				s.mu.Lock()
+				s.redraw()
+				fmt.Fprint(s.out, "\n")
+				s.mu.Unlock()
+				return
+			}
+			s.mu.Lock()
+			s.workers[ev.WorkerID] = ev
+			s.processed++
+			s.bytesRead += ev.Size
+			s.redraw()
+			s.mu.Unlock()
+		case <-ticker.C:
+			s.mu.Lock()
+			s.redraw()
+			s.mu.Unlock()
+		}
+	}
+}
+
+// redraw reposiciona o cursor no início da área de status e reescreve todas
+// as linhas. Deve ser chamado com s.mu travado.
+func (s *Status) redraw() {
+	s.clear()
+
+	elapsed := time.Since(s.start)
+	var eta time.Duration
+	var filesPerSec, mbPerSec float64
+	if s.processed > 0 && s.total > 0 {
+		perFile := elapsed / time.Duration(s.processed)
+		eta = perFile * time.Duration(s.total-s.processed)
+		filesPerSec = float64(s.processed) / elapsed.Seconds()
+	}
+	if elapsed.Seconds() > 0 {
+		mbPerSec = float64(s.bytesRead) / (1024 * 1024) / elapsed.Seconds()
+	}
+
+	fmt.Fprintf(s.out, "[%s] %d/%d arquivos  ETA %s  %.1f MB/s  %.1f arquivos/s\n",
+		elapsed.Round(time.Second), s.processed, s.total, eta.Round(time.Second), mbPerSec, filesPerSec)
+	lines := 1
+
+	for i, w := range s.workers {
+		if w.File == "" {
+			fmt.Fprintf(s.out, "  worker %d: ocioso\n", i)
+		} else {
+			fmt.Fprintf(s.out, "  worker %d: [%s] %s (%s)\n", i, w.Phase, w.File, formatSize(w.Size))
+		}
+		lines++
+	}
+	s.lastLines = lines
+}
+
+// clear apaga as linhas desenhadas na última chamada a redraw, para que a
+// próxima escrita comece do topo da área de status.
+func (s *Status) clear() {
+	if s.lastLines == 0 {
+		return
+	}
+	fmt.Fprintf(s.out, "\x1b[%dA\x1b[J", s.lastLines)
+	s.lastLines = 0
+}
+
+func formatSize(size int64) string {
+	if size <= 0 {
+		return "0 B"
+	}
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+// Printf imprime uma mensagem acima da área de status sem corrompê-la: a
+// área é apagada, a mensagem é escrita e o status é redesenhado em seguida.
+// Seguro para uso concorrente, inclusive a partir dos workers que também
+// enviam Events.
+func (s *Status) Printf(format string, args ...interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.interactive {
+		s.clear()
+	}
+	fmt.Fprintf(s.out, format, args...)
+	if s.interactive {
+		s.redraw()
+	}
+}